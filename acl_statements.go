@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// aclSelector is the structured form of a Redis 7 ACL selector: an
+// additional, independently scoped set of key/channel/command rules applied
+// to a user alongside its root permissions.
+type aclSelector struct {
+	KeyPatterns       []string `json:"key_patterns"`
+	ChannelPatterns   []string `json:"channel_patterns"`
+	CommandCategories []string `json:"command_categories"`
+	Commands          []string `json:"commands"`
+}
+
+// aclCreationStatement is the versioned, structured replacement for the
+// legacy "JSON array of raw ACL tokens" creation_statements format. It
+// renders to the ACL SETUSER tokens Redis 7 expects instead of requiring
+// operators to hand-assemble them.
+type aclCreationStatement struct {
+	KeyPatterns       []string      `json:"key_patterns"`
+	ChannelPatterns   []string      `json:"channel_patterns"`
+	HashPatterns      []string      `json:"hash_patterns"`
+	CommandCategories []string      `json:"command_categories"`
+	Commands          []string      `json:"commands"`
+	Selectors         []aclSelector `json:"selectors"`
+	Reset             bool          `json:"reset"`
+	NoPass            bool          `json:"nopass"`
+}
+
+// parsedACL is the result of parsing a role's raw creation_statements entry.
+// Reset is split out from Tokens because `ACL SETUSER reset` is equivalent
+// to `off resetpass resetkeys resetchannels -@all`: Redis applies SETUSER
+// tokens left to right, so reset must be emitted before ON/the password
+// rather than folded in with the rest of the tokens, or it would immediately
+// undo them.
+type parsedACL struct {
+	Reset  bool
+	Tokens []interface{}
+}
+
+// parseACLArgs turns a role's raw creation_statements entry into the tokens
+// to append after `ACL SETUSER <username>`. It detects the legacy
+// JSON-array-of-tokens format by its leading '[' and falls through to that
+// parser for backward compatibility; anything else is parsed as a
+// structured aclCreationStatement and validated against the live server's
+// known categories and commands before it's rendered, so a misconfigured
+// role fails in NewUser instead of producing a cryptic Redis ACL error.
+func parseACLArgs(ctx context.Context, db goredis.UniversalClient, raw string) (parsedACL, error) {
+	trimmed := strings.TrimSpace(raw)
+
+	if strings.HasPrefix(trimmed, "[") {
+		var legacy []string
+		if err := json.Unmarshal([]byte(trimmed), &legacy); err != nil {
+			return parsedACL{}, errwrap.Wrapf("error unmarshalling REDIS rules in the creation statement JSON: {{err}}", err)
+		}
+		tokens := make([]interface{}, len(legacy))
+		for i, t := range legacy {
+			tokens[i] = t
+		}
+		return parsedACL{Tokens: tokens}, nil
+	}
+
+	var stmt aclCreationStatement
+	if err := json.Unmarshal([]byte(trimmed), &stmt); err != nil {
+		return parsedACL{}, fmt.Errorf("error unmarshalling REDIS ACL creation statement: %w", err)
+	}
+
+	if err := stmt.validate(ctx, db); err != nil {
+		return parsedACL{}, err
+	}
+
+	return parsedACL{Reset: stmt.Reset, Tokens: stmt.render()}, nil
+}
+
+// validate checks every command category and command name referenced by the
+// statement, including its selectors, against the live server's `ACL CAT`
+// and `COMMAND LIST` output.
+func (s *aclCreationStatement) validate(ctx context.Context, db goredis.UniversalClient) error {
+	categories := append([]string{}, s.CommandCategories...)
+	commands := append([]string{}, s.Commands...)
+	for _, sel := range s.Selectors {
+		categories = append(categories, sel.CommandCategories...)
+		commands = append(commands, sel.Commands...)
+	}
+
+	if len(categories) > 0 {
+		known, err := db.Do(ctx, "ACL", "CAT").StringSlice()
+		if err != nil {
+			return fmt.Errorf("failed to list ACL categories: %w", err)
+		}
+		knownSet := toSet(known)
+		for _, cat := range categories {
+			if !knownSet[strings.ToLower(cat)] {
+				return fmt.Errorf("unknown ACL command category %q", cat)
+			}
+		}
+	}
+
+	if len(commands) > 0 {
+		known, err := db.Do(ctx, "COMMAND", "LIST").StringSlice()
+		if err != nil {
+			return fmt.Errorf("failed to list commands: %w", err)
+		}
+		knownSet := toSet(known)
+		for _, cmd := range commands {
+			name := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(cmd, "+"), "-"))
+			name = strings.SplitN(name, "|", 2)[0]
+			if !knownSet[name] {
+				return fmt.Errorf("unknown REDIS command %q", cmd)
+			}
+		}
+	}
+
+	return nil
+}
+
+// render produces the ACL SETUSER tokens for this statement, in the order
+// Redis documents them: the nopass flag, then key/channel/hash patterns,
+// then command categories and commands, then selectors. Reset is handled
+// separately by the caller; see parsedACL.
+func (s *aclCreationStatement) render() []interface{} {
+	var tokens []interface{}
+
+	if s.NoPass {
+		tokens = append(tokens, "nopass")
+	}
+	for _, p := range s.KeyPatterns {
+		tokens = append(tokens, "~"+p)
+	}
+	for _, p := range s.ChannelPatterns {
+		tokens = append(tokens, "&"+p)
+	}
+	for _, p := range s.HashPatterns {
+		tokens = append(tokens, "%R~"+p)
+	}
+	for _, cat := range s.CommandCategories {
+		tokens = append(tokens, "+@"+cat)
+	}
+	for _, cmd := range s.Commands {
+		tokens = append(tokens, normalizeCommandToken(cmd))
+	}
+	for _, sel := range s.Selectors {
+		tokens = append(tokens, renderSelector(sel))
+	}
+
+	return tokens
+}
+
+// normalizeCommandToken defaults a bare command name to an allow rule
+// (+cmd), while leaving an explicit +cmd/-cmd token untouched.
+func normalizeCommandToken(cmd string) string {
+	if strings.HasPrefix(cmd, "+") || strings.HasPrefix(cmd, "-") {
+		return cmd
+	}
+	return "+" + cmd
+}
+
+// renderSelector renders a single `(selector ...)` token.
+func renderSelector(sel aclSelector) string {
+	var parts []string
+	for _, p := range sel.KeyPatterns {
+		parts = append(parts, "~"+p)
+	}
+	for _, p := range sel.ChannelPatterns {
+		parts = append(parts, "&"+p)
+	}
+	for _, cat := range sel.CommandCategories {
+		parts = append(parts, "+@"+cat)
+	}
+	for _, cmd := range sel.Commands {
+		parts = append(parts, normalizeCommandToken(cmd))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseACLArgs_LegacyArrayFormat(t *testing.T) {
+	parsed, err := parseACLArgs(context.Background(), nil, `["~*", "+@read"]`)
+	if err != nil {
+		t.Fatalf("parseACLArgs returned error: %v", err)
+	}
+	if parsed.Reset {
+		t.Fatal("legacy array format should never set Reset")
+	}
+	if len(parsed.Tokens) != 2 || parsed.Tokens[0] != "~*" || parsed.Tokens[1] != "+@read" {
+		t.Fatalf("unexpected tokens: %v", parsed.Tokens)
+	}
+}
+
+func TestParseACLArgs_StructuredFormat(t *testing.T) {
+	raw := `{
+		"reset": true,
+		"key_patterns": ["foo:*"],
+		"channel_patterns": ["chan:*"],
+		"hash_patterns": ["bar:*"],
+		"selectors": [
+			{"key_patterns": ["sel:*"]}
+		]
+	}`
+
+	// No categories/commands are set, so validate never calls ACL CAT /
+	// COMMAND LIST and parseACLArgs needs no live connection; db can stay nil.
+	parsed, err := parseACLArgs(context.Background(), nil, raw)
+	if err != nil {
+		t.Fatalf("parseACLArgs returned error: %v", err)
+	}
+	if !parsed.Reset {
+		t.Fatal("expected Reset to be split out of the rendered tokens")
+	}
+
+	want := []interface{}{"~foo:*", "&chan:*", "%R~bar:*", "(~sel:*)"}
+	if len(parsed.Tokens) != len(want) {
+		t.Fatalf("got tokens %v, want %v", parsed.Tokens, want)
+	}
+	for i := range want {
+		if parsed.Tokens[i] != want[i] {
+			t.Fatalf("token %d: got %v, want %v", i, parsed.Tokens[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeCommandToken(t *testing.T) {
+	cases := map[string]string{
+		"get":       "+get",
+		"+get":      "+get",
+		"-flushall": "-flushall",
+	}
+	for in, want := range cases {
+		if got := normalizeCommandToken(in); got != want {
+			t.Errorf("normalizeCommandToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenderSelector(t *testing.T) {
+	sel := aclSelector{
+		KeyPatterns:       []string{"a:*"},
+		ChannelPatterns:   []string{"b:*"},
+		CommandCategories: []string{"read"},
+		Commands:          []string{"get"},
+	}
+	got := renderSelector(sel)
+	want := "(~a:* &b:* +@read +get)"
+	if got != want {
+		t.Fatalf("renderSelector() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// redisDBConnectionProducer implements the connection handling logic shared by
+// the RedisDB plugin. It builds a single go-redis UniversalClient from the
+// config block, so roles can point at a standalone Redis instance, a Redis
+// Cluster, or a Sentinel-managed deployment without anything else in the
+// plugin needing to know which topology is in play.
+type redisDBConnectionProducer struct {
+	Host     string `json:"host" mapstructure:"host" structs:"host"`
+	Port     int    `json:"port" mapstructure:"port" structs:"port"`
+	Username string `json:"username" mapstructure:"username" structs:"username"`
+	Password string `json:"password" mapstructure:"password" structs:"password"`
+
+	// Cluster, when set, causes Initialize to build a cluster client seeded
+	// from Addresses instead of a single client dialed against Host/Port.
+	Cluster   bool     `json:"cluster" mapstructure:"cluster" structs:"cluster"`
+	Addresses []string `json:"addresses" mapstructure:"addresses" structs:"addresses"`
+
+	// Sentinels, when set, causes Initialize to build a failover client that
+	// discovers the current master for MasterName through the listed
+	// sentinel addresses, instead of dialing Host/Port or Addresses
+	// directly. The client re-resolves the master on failover, so callers
+	// always talk to whichever node is currently primary.
+	Sentinels        []string `json:"sentinels" mapstructure:"sentinels" structs:"sentinels"`
+	MasterName       string   `json:"master_name" mapstructure:"master_name" structs:"master_name"`
+	SentinelPassword string   `json:"sentinel_password" mapstructure:"sentinel_password" structs:"sentinel_password"`
+
+	// TLS, when set, causes Initialize to dial every client with TLS
+	// enabled. InsecureTLS skips server certificate verification entirely;
+	// CACert, ClientCert, ClientKey, and TLSServerName are otherwise used
+	// to build the *tls.Config passed to go-redis.
+	TLS           bool   `json:"tls" mapstructure:"tls" structs:"tls"`
+	InsecureTLS   bool   `json:"insecure_tls" mapstructure:"insecure_tls" structs:"insecure_tls"`
+	CACert        string `json:"ca_cert" mapstructure:"ca_cert" structs:"ca_cert"`
+	ClientCert    string `json:"client_cert" mapstructure:"client_cert" structs:"client_cert"`
+	ClientKey     string `json:"client_key" mapstructure:"client_key" structs:"client_key"`
+	TLSServerName string `json:"tls_server_name" mapstructure:"tls_server_name" structs:"tls_server_name"`
+
+	// ManageExistingPasswords opts a static role's connection in to
+	// destructive password rotation: stripping every password already set
+	// on a pre-existing user (ACL RESETPASS) before adding the new one.
+	// When false, UpdateUser only appends the new password, leaving any
+	// password the user already had valid.
+	ManageExistingPasswords bool `json:"manage_existing_passwords" mapstructure:"manage_existing_passwords" structs:"manage_existing_passwords"`
+
+	Type string
+
+	Initialized bool
+	rawConfig   map[string]interface{}
+
+	client goredis.UniversalClient
+
+	sync.Mutex
+}
+
+// Initialize parses conf into the producer's fields and builds the
+// UniversalClient for the configured topology. When verifyConnection is true
+// the new client is exercised with a PING before Initialize returns.
+func (c *redisDBConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rawConfig = conf
+
+	if err := mapstructure.WeakDecode(conf, c); err != nil {
+		return err
+	}
+
+	opts := &goredis.UniversalOptions{
+		Username: c.Username,
+		Password: c.Password,
+	}
+
+	if c.TLS {
+		tlsConfig, err := c.buildTLSConfig()
+		if err != nil {
+			return fmt.Errorf("error building TLS config: %w", err)
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	switch {
+	case len(c.Sentinels) > 0:
+		if c.MasterName == "" {
+			return fmt.Errorf("master_name is required when sentinels is set")
+		}
+		opts.Addrs = c.Sentinels
+		opts.MasterName = c.MasterName
+		opts.SentinelPassword = c.SentinelPassword
+	case c.Cluster:
+		if len(c.Addresses) == 0 {
+			return fmt.Errorf("addresses must be set when cluster is true")
+		}
+		opts.Addrs = c.Addresses
+	default:
+		if c.Host == "" {
+			return fmt.Errorf("host is required")
+		}
+		opts.Addrs = []string{c.Host + ":" + strconv.Itoa(c.Port)}
+	}
+
+	c.client = goredis.NewUniversalClient(opts)
+	c.Initialized = true
+
+	if verifyConnection {
+		if err := c.client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("error verifying connection: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// buildTLSConfig assembles a *tls.Config from the producer's TLS fields:
+// InsecureTLS disables server certificate verification, CACert pins the
+// trusted CA, ClientCert/ClientKey enable mTLS, and TLSServerName overrides
+// SNI when it differs from Host (e.g. dialing through a proxy).
+func (c *redisDBConnectionProducer) buildTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureTLS,
+		ServerName:         c.TLSServerName,
+	}
+
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+			return nil, fmt.Errorf("failed to parse ca_cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client_cert/client_key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Connection returns the UniversalClient built in Initialize. It transparently
+// wraps a single-node client, a failover (Sentinel) client, or a cluster
+// client depending on how the producer was configured.
+func (c *redisDBConnectionProducer) Connection(ctx context.Context) (goredis.UniversalClient, error) {
+	if !c.Initialized {
+		return nil, fmt.Errorf("connection producer is not initialized")
+	}
+	return c.client, nil
+}
+
+// close tears down the client Initialize created.
+func (c *redisDBConnectionProducer) close() error {
+	if c.client != nil {
+		return c.client.Close()
+	}
+	return nil
+}
+
+// secretValues is handed to dbplugin.NewDatabaseErrorSanitizerMiddleware so
+// that the configured passwords are never echoed back in a sanitized error.
+func (c *redisDBConnectionProducer) secretValues() map[string]string {
+	return map[string]string{
+		c.Password:         "[password]",
+		c.SentinelPassword: "[sentinel_password]",
+		c.ClientKey:        "[client_key]",
+	}
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConnectionProducer_Initialize_Sentinel(t *testing.T) {
+	c := &redisDBConnectionProducer{}
+
+	conf := map[string]interface{}{
+		"sentinels":         []string{"sentinel1:26379", "sentinel2:26379"},
+		"master_name":       "mymaster",
+		"sentinel_password": "sentinelpw",
+	}
+
+	if err := c.Initialize(context.Background(), conf, false); err != nil {
+		t.Fatalf("Initialize returned error: %v", err)
+	}
+
+	if !c.Initialized {
+		t.Fatal("expected producer to be marked initialized")
+	}
+
+	client, err := c.Connection(context.Background())
+	if err != nil {
+		t.Fatalf("Connection returned error: %v", err)
+	}
+	if client == nil {
+		t.Fatal("expected a non-nil client for a sentinel configuration")
+	}
+}
+
+func TestConnectionProducer_Initialize_SentinelRequiresMasterName(t *testing.T) {
+	c := &redisDBConnectionProducer{}
+
+	conf := map[string]interface{}{
+		"sentinels": []string{"sentinel1:26379"},
+	}
+
+	if err := c.Initialize(context.Background(), conf, false); err == nil {
+		t.Fatal("expected an error when sentinels is set without master_name")
+	}
+}
+
+func TestConnectionProducer_Initialize_Cluster(t *testing.T) {
+	c := &redisDBConnectionProducer{}
+
+	conf := map[string]interface{}{
+		"cluster":   true,
+		"addresses": []string{"redis1:6379", "redis2:6379"},
+	}
+
+	if err := c.Initialize(context.Background(), conf, false); err != nil {
+		t.Fatalf("Initialize returned error: %v", err)
+	}
+}
+
+func TestConnectionProducer_Initialize_RequiresHostWithoutClusterOrSentinel(t *testing.T) {
+	c := &redisDBConnectionProducer{}
+
+	if err := c.Initialize(context.Background(), map[string]interface{}{}, false); err == nil {
+		t.Fatal("expected an error when host is unset and neither cluster nor sentinels are configured")
+	}
+}
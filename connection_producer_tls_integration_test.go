@@ -0,0 +1,82 @@
+//go:build integration
+
+package redis
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// TestConnectionProducer_TLS_Integration exercises Initialize against a real
+// TLS-terminating endpoint (e.g. a stunnel-fronted Redis), verifying
+// handshake success, bad-CA rejection, and SNI selection end-to-end, none of
+// which the offline unit tests in connection_producer_tls_test.go can cover
+// since they only check the *tls.Config fields buildTLSConfig produces. It's
+// gated behind REDIS_TLS_HOST and skipped otherwise, so `go test ./...`
+// stays fully offline by default.
+func TestConnectionProducer_TLS_Integration(t *testing.T) {
+	host := os.Getenv("REDIS_TLS_HOST")
+	if host == "" {
+		t.Skip("set REDIS_TLS_HOST (and REDIS_TLS_PORT, REDIS_TLS_CA_CERT_FILE, REDIS_TLS_SERVER_NAME) to run against a live TLS-terminating endpoint")
+	}
+	port, err := strconv.Atoi(os.Getenv("REDIS_TLS_PORT"))
+	if err != nil {
+		t.Fatalf("REDIS_TLS_PORT must be set to a valid port: %v", err)
+	}
+
+	caCert := ""
+	if path := os.Getenv("REDIS_TLS_CA_CERT_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read REDIS_TLS_CA_CERT_FILE: %v", err)
+		}
+		caCert = string(data)
+	}
+
+	t.Run("handshake succeeds with a trusted CA and correct SNI", func(t *testing.T) {
+		c := &redisDBConnectionProducer{}
+		conf := map[string]interface{}{
+			"host":            host,
+			"port":            port,
+			"tls":             true,
+			"ca_cert":         caCert,
+			"tls_server_name": os.Getenv("REDIS_TLS_SERVER_NAME"),
+		}
+		if err := c.Initialize(context.Background(), conf, true); err != nil {
+			t.Fatalf("expected a successful TLS handshake, got: %v", err)
+		}
+		defer c.close()
+	})
+
+	t.Run("handshake is rejected against an untrusted CA", func(t *testing.T) {
+		c := &redisDBConnectionProducer{}
+		conf := map[string]interface{}{
+			"host": host,
+			"port": port,
+			"tls":  true,
+			// Deliberately omit ca_cert/insecure_tls so the system root pool,
+			// which won't trust a self-signed stunnel cert, is used instead.
+		}
+		if err := c.Initialize(context.Background(), conf, true); err == nil {
+			defer c.close()
+			t.Fatal("expected the handshake to fail against an untrusted CA")
+		}
+	})
+
+	t.Run("handshake is rejected for the wrong SNI name", func(t *testing.T) {
+		c := &redisDBConnectionProducer{}
+		conf := map[string]interface{}{
+			"host":            host,
+			"port":            port,
+			"tls":             true,
+			"ca_cert":         caCert,
+			"tls_server_name": "wrong-server-name.invalid",
+		}
+		if err := c.Initialize(context.Background(), conf, true); err == nil {
+			defer c.close()
+			t.Fatal("expected the handshake to fail for a certificate that doesn't match the requested SNI name")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// selfSignedPEM generates a throwaway self-signed certificate and key pair,
+// PEM-encoded, so buildTLSConfig can be exercised without any fixture files.
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "redis-test"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	var certBuf bytes.Buffer
+	if err := pem.Encode(&certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+		t.Fatalf("failed to encode certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	var keyBuf bytes.Buffer
+	if err := pem.Encode(&keyBuf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}); err != nil {
+		t.Fatalf("failed to encode key: %v", err)
+	}
+
+	return certBuf.String(), keyBuf.String()
+}
+
+func TestBuildTLSConfig_InsecureTLS(t *testing.T) {
+	c := &redisDBConnectionProducer{InsecureTLS: true}
+
+	tlsConfig, err := c.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned error: %v", err)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Fatal("expected InsecureSkipVerify to be set")
+	}
+}
+
+func TestBuildTLSConfig_ServerName(t *testing.T) {
+	c := &redisDBConnectionProducer{TLSServerName: "redis.example.com"}
+
+	tlsConfig, err := c.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned error: %v", err)
+	}
+	if tlsConfig.ServerName != "redis.example.com" {
+		t.Fatalf("got ServerName %q, want %q", tlsConfig.ServerName, "redis.example.com")
+	}
+}
+
+func TestBuildTLSConfig_CACert(t *testing.T) {
+	certPEM, _ := selfSignedPEM(t)
+	c := &redisDBConnectionProducer{CACert: certPEM}
+
+	tlsConfig, err := c.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned error: %v", err)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Fatal("expected RootCAs to be populated from ca_cert")
+	}
+}
+
+func TestBuildTLSConfig_InvalidCACert(t *testing.T) {
+	c := &redisDBConnectionProducer{CACert: "not a pem cert"}
+
+	if _, err := c.buildTLSConfig(); err == nil {
+		t.Fatal("expected an error for an unparseable ca_cert")
+	}
+}
+
+func TestBuildTLSConfig_ClientCertAndKey(t *testing.T) {
+	certPEM, keyPEM := selfSignedPEM(t)
+	c := &redisDBConnectionProducer{ClientCert: certPEM, ClientKey: keyPEM}
+
+	tlsConfig, err := c.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned error: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected one client certificate, got %d", len(tlsConfig.Certificates))
+	}
+}
+
+func TestBuildTLSConfig_MismatchedClientCertAndKey(t *testing.T) {
+	certPEM, _ := selfSignedPEM(t)
+	_, keyPEM := selfSignedPEM(t)
+	c := &redisDBConnectionProducer{ClientCert: certPEM, ClientKey: keyPEM}
+
+	if _, err := c.buildTLSConfig(); err == nil {
+		t.Fatal("expected an error when client_cert and client_key don't match")
+	}
+}
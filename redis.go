@@ -2,23 +2,20 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
-	"errors"
-	
-	"github.com/mediocregopher/radix/v3"
-	"github.com/mediocregopher/radix/v3/resp/resp2"
-	"github.com/hashicorp/errwrap"
+
 	hclog "github.com/hashicorp/go-hclog"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 const (
 	redisTypeName        = "redis"
-	defaultRedisUserRule  = `["~*", "+@read"]`
+	defaultRedisUserRule = `["~*", "+@read"]`
 	defaultTimeout       = 20000 * time.Millisecond
 	maxKeyLength         = 64
 )
@@ -32,6 +29,13 @@ var (
 type RedisDB struct {
 	*redisDBConnectionProducer
 	credsutil.CredentialsProducer
+
+	// createdUsers tracks usernames this plugin instance created via
+	// NewUser, so changeUserPassword can tell a dynamic user (safe to
+	// fully rotate) apart from a static role's pre-existing user (only
+	// wiped on RESETPASS if ManageExistingPasswords opts in). Access is
+	// guarded by the embedded connection producer's mutex.
+	createdUsers map[string]bool
 }
 
 // New implements builtinplugins.BuiltinFactory
@@ -48,6 +52,13 @@ func new() *RedisDB {
 
 	db := &RedisDB{
 		redisDBConnectionProducer: connProducer,
+		CredentialsProducer: &credsutil.SQLCredentialsProducer{
+			DisplayNameLen: maxKeyLength,
+			RoleNameLen:    maxKeyLength,
+			UsernameLen:    maxKeyLength,
+			Separator:      "-",
+		},
+		createdUsers: make(map[string]bool),
 	}
 
 	return db
@@ -86,6 +97,7 @@ func (c *RedisDB) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbp
 	if err != nil {
 		return dbplugin.NewUserResponse{}, err
 	}
+	c.createdUsers[username] = true
 
 	resp := dbplugin.NewUserResponse{
 		Username: username,
@@ -95,18 +107,27 @@ func (c *RedisDB) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbp
 }
 
 func (c *RedisDB) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
-	if req.Password != nil {
-		err := c.changeUserPassword(ctx, req.Username, req.Password.NewPassword)
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+
+	if req.Username == c.Username {
+		// Vault's database secrets engine rotates the connection's own root
+		// credentials through this same RPC rather than a dedicated one, so
+		// root rotation is routed here instead of a standalone method.
+		err := c.rotateRootCredentials(ctx, req.Password.NewPassword)
 		return dbplugin.UpdateUserResponse{}, err
 	}
-	return dbplugin.UpdateUserResponse{}, nil
+
+	err := c.changeUserPassword(ctx, req.Username, req.Password.NewPassword)
+	return dbplugin.UpdateUserResponse{}, err
 }
 
 func (c *RedisDB) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
 	c.Lock()
 	defer c.Unlock()
 
-	db, err := c.getConnection(ctx) 
+	db, err := c.getConnection(ctx)
 	if err != nil {
 		return dbplugin.DeleteUserResponse{}, fmt.Errorf("failed to make connection: %w", err)
 	}
@@ -119,75 +140,78 @@ func (c *RedisDB) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest
 		}
 	}()
 
-	var response string
-
-	switch db.(type) {
-
-	case *radix.Pool:
-		err = db.Do(radix.Cmd(&response, "ACL", "DELUSER", req.Username))
-		if err != nil {
-			return dbplugin.DeleteUserResponse{}, fmt.Errorf("response from pool DeleteUser: %s, error: %w", response, err)
-		}
-	case *radix.Cluster:
-		topo := db.(*radix.Cluster).Topo()
-		nodes := topo.Map()
-		for node := range nodes {
-			cl, err := db.(*radix.Cluster).Client(node)
-			err = cl.Do(radix.Cmd(&response, "ACL", "DELUSER", req.Username))
-			if err != nil {
-				return dbplugin.DeleteUserResponse{}, fmt.Errorf("response from cluster node %s for DeleteUser: %s, error: %w", node, response, err)
-			}
-			
-		}
+	err = eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+		return shard.Do(ctx, "ACL", "DELUSER", req.Username).Err()
+	})
+	if err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("error deleting user %s: %w", req.Username, err)
 	}
+	delete(c.createdUsers, req.Username)
+
 	return dbplugin.DeleteUserResponse{}, nil
 }
 
-func newUser(ctx context.Context, db radix.Client, username string, req dbplugin.NewUserRequest) error {
+func newUser(ctx context.Context, db goredis.UniversalClient, username string, req dbplugin.NewUserRequest) error {
 	statements := removeEmpty(req.Statements.Commands)
 	if len(statements) == 0 {
 		statements = append(statements, defaultRedisUserRule)
 	}
-	// setup REDIS command
-	aclargs := []string{"SETUSER", username, "ON", ">" + req.Password}
 
-	var args []string
-	err := json.Unmarshal([]byte(statements[0]), &args)
+	parsed, err := parseACLArgs(ctx, db, statements[0])
 	if err != nil {
-		return errwrap.Wrapf("error unmarshalling REDIS rules in the creation statement JSON: {{err}}", err)
+		return err
 	}
 
-	// append the additional rules/permissions
-	aclargs = append(aclargs, args...)
+	// setup REDIS command. reset must come before ON/the password: see the
+	// parsedACL doc comment for why folding it into the tail args is wrong.
+	aclargs := []interface{}{"ACL", "SETUSER", username}
+	if parsed.Reset {
+		aclargs = append(aclargs, "reset")
+	}
+	aclargs = append(aclargs, "ON", ">"+req.Password)
+	aclargs = append(aclargs, parsed.Tokens...)
 
-	var response string
+	return eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+		return shard.Do(ctx, aclargs...).Err()
+	})
+}
 
-	switch db.(type) {
+// rotateRootCredentials applies newPassword to the root user this plugin
+// connects as, across every shard the UniversalClient fans out to, and
+// updates the producer's own copy of the password on success. If any shard
+// fails to accept the new password, every shard is reset back to the old
+// password; this is safe even for shards that never rotated, since
+// RESETPASS followed by the same password they already have is a no-op.
+func (c *RedisDB) rotateRootCredentials(ctx context.Context, newPassword string) error {
+	c.Lock()
+	defer c.Unlock()
 
-	case *radix.Pool:
-		err = db.Do(radix.Cmd(&response, "ACL", aclargs...))
+	if c.Username == "" {
+		return fmt.Errorf("unable to rotate root credentials: no username set in configuration")
+	}
 
-		fmt.Printf("Response in newUser: %s\n", response)
-	
-		if err != nil {
-			return err
-		}
-	case *radix.Cluster:
-		topo := db.(*radix.Cluster).Topo()
-		nodes := topo.Map()
-		for node := range nodes {
-			cl, err := db.(*radix.Cluster).Client(node)
-			err = cl.Do(radix.Cmd(&response, "ACL", aclargs...))
-
-			fmt.Printf("Response in cluster newUser: %s\n", response)
-			
-			if err != nil {
-				return err
-			}
-			
+	db, err := c.getConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	err = eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+		return shard.Do(ctx, "ACL", "SETUSER", c.Username, "RESETPASS", ">"+newPassword).Err()
+	})
+	if err != nil {
+		rollbackErr := eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+			return shard.Do(ctx, "ACL", "SETUSER", c.Username, "RESETPASS", ">"+c.Password).Err()
+		})
+		if rollbackErr != nil {
+			logger := hclog.New(&hclog.LoggerOptions{})
+			logger.Error("failed to roll back root password after partial rotation failure", "error", rollbackErr)
 		}
+		return fmt.Errorf("failed to rotate root credentials: %w", err)
 	}
-	
+
+	c.Password = newPassword
+	c.rawConfig["password"] = newPassword
+
 	return nil
 }
 
@@ -208,84 +232,83 @@ func (c *RedisDB) changeUserPassword(ctx context.Context, username, password str
 		}
 	}()
 
-	var response resp2.Array
-	var redisErr resp2.Error
-	mn := radix.MaybeNil{Rcv: &response}
-	
-	switch db.(type) {
+	exists, err := aclUserExists(ctx, db, username)
+	if err != nil {
+		return fmt.Errorf("reset of passwords for user %s failed in changeUserPassword: %w", username, err)
+	}
+	if !exists {
+		return fmt.Errorf("changeUserPassword for user %s failed, user not found", username)
+	}
 
-	case *radix.Pool:
+	aclargs := []interface{}{"ACL", "SETUSER", username}
+	if shouldResetPassword(username, c.createdUsers, c.ManageExistingPasswords) {
+		aclargs = append(aclargs, "RESETPASS")
+	}
+	aclargs = append(aclargs, ">"+password)
 
-		err = db.Do(radix.Cmd(&mn, "ACL", "GETUSER", username))
-		if errors.As(err, &redisErr) {
-			fmt.Printf("redis error returned: %s", redisErr.E)
-		}
+	err = eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+		return shard.Do(ctx, aclargs...).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("reset of password for user %s failed: %w", username, err)
+	}
 
+	return nil
+}
 
-		if err != nil {
-			return fmt.Errorf("reset of passwords for user %s failed in changeUserPassword: %w", username, err)
-		}
+// shouldResetPassword reports whether changeUserPassword should wipe a
+// user's existing passwords with RESETPASS before adding the new one.
+// Dynamic users this plugin created via NewUser are always fully rotated,
+// same as before static roles existed. Static roles rotate users Vault
+// didn't create, which may already carry passwords unknown to Vault, so
+// those are only wiped once the operator has explicitly opted in via
+// ManageExistingPasswords.
+func shouldResetPassword(username string, createdUsers map[string]bool, manageExistingPasswords bool) bool {
+	return createdUsers[username] || manageExistingPasswords
+}
 
-		if mn.Nil {
-			return fmt.Errorf("changeUserPassword for user %s failed, user not found!", username);
+// errUserMissing is returned by the eachMaster callback in aclUserExists to
+// signal a shard reporting the user as absent, without writing to any state
+// shared across goroutines: for a *goredis.ClusterClient, ForEachMaster runs
+// the callback concurrently, one goroutine per master.
+var errUserMissing = errors.New("acl user missing")
+
+// aclUserExists reports whether username is known to every master shard
+// reachable through db.
+func aclUserExists(ctx context.Context, db goredis.UniversalClient, username string) (bool, error) {
+	err := eachMaster(ctx, db, func(ctx context.Context, shard *goredis.Client) error {
+		res, err := shard.Do(ctx, "ACL", "GETUSER", username).Result()
+		if err != nil && err != goredis.Nil {
+			return err
 		}
-
-	case *radix.Cluster:
-		topo := db.(*radix.Cluster).Topo()
-		nodes := topo.Map()
-		for node := range nodes {
-			cl, err := db.(*radix.Cluster).Client(node)
-			//err = cl.Do(radix.Cmd(&response, "ACL", "DELUSER", req.Username))
-			//fmt.Printf("Response in cluster DeleteUser: %s\n", response)
-			
-			//if err != nil {
-			//	return dbplugin.DeleteUserResponse{}, err
-			//}
-			err = cl.Do(radix.Cmd(&mn, "ACL", "GETUSER", username))
-			if errors.As(err, &redisErr) {
-				fmt.Printf("redis error returned: %s", redisErr.E)
-			}
-			
-			
-			if err != nil {
-				return fmt.Errorf("reset of passwords for user %s failed in changeUserPassword on cluster member %s: %w", username, node, err)
-			}
-			
-			if mn.Nil {
-				return fmt.Errorf("changeUserPassword for user %s failed on cluster member %s, user not found!", node, username);
-			}
+		if err == goredis.Nil || res == nil {
+			return errUserMissing
 		}
+		return nil
+	})
+	if errors.Is(err, errUserMissing) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
 
-	var sresponse string
-	switch db.(type) {
-
-	case *radix.Pool:
-		err = db.Do(radix.Cmd(&sresponse, "ACL", "SETUSER", username, "RESETPASS", ">" + password))
-
-		fmt.Printf("Response in changeUserPassword2: %s\n", sresponse)
-
-		if err != nil {
-			return fmt.Errorf("pool reset of password for user %s failed, REDIS response %s, error, %s", username, sresponse, err)
-		}
-
-	case *radix.Cluster:
-		topo := db.(*radix.Cluster).Topo()
-		nodes := topo.Map()
-		for node := range nodes {
-			cl, err := db.(*radix.Cluster).Client(node)
-
-			err = cl.Do(radix.Cmd(&sresponse, "ACL", "SETUSER", username, "RESETPASS", ">" + password))
-
-			fmt.Printf("Response in changeUserPassword2: %s\n", sresponse)
+	return true, nil
+}
 
-			if err != nil {
-				return fmt.Errorf("cluster reset of password for user %s on node %s failed, REDIS response %s, error, %s", username, node, sresponse, err)
-			}
-		}
+// eachMaster runs fn against every master shard reachable through db. For a
+// cluster client this fans out via ForEachMaster; for a single-node or
+// Sentinel-backed client, which go-redis represents as a plain *redis.Client
+// under the UniversalClient interface, fn just runs once.
+func eachMaster(ctx context.Context, db goredis.UniversalClient, fn func(ctx context.Context, shard *goredis.Client) error) error {
+	switch cl := db.(type) {
+	case *goredis.ClusterClient:
+		return cl.ForEachMaster(ctx, fn)
+	case *goredis.Client:
+		return fn(ctx, cl)
+	default:
+		return fmt.Errorf("unsupported redis client type %T", db)
 	}
-
-	return nil
 }
 
 func removeEmpty(strs []string) []string {
@@ -309,7 +332,7 @@ func computeTimeout(ctx context.Context) (timeout time.Duration) {
 	return defaultTimeout
 }
 
-func (c *RedisDB) getConnection(ctx context.Context) (radix.Client, error) {
+func (c *RedisDB) getConnection(ctx context.Context) (goredis.UniversalClient, error) {
 	client, err := c.Connection(ctx)
 	if err != nil {
 		return nil, err
@@ -320,3 +343,9 @@ func (c *RedisDB) getConnection(ctx context.Context) (radix.Client, error) {
 func (c *RedisDB) Type() (string, error) {
 	return redisTypeName, nil
 }
+
+// Close attempts to close the underlying database connection that was
+// established by the backend.
+func (c *RedisDB) Close() error {
+	return c.close()
+}
@@ -0,0 +1,22 @@
+package redis
+
+import "testing"
+
+func TestShouldResetPassword_CreatedUserAlwaysReset(t *testing.T) {
+	createdUsers := map[string]bool{"dynamic-user": true}
+
+	if !shouldResetPassword("dynamic-user", createdUsers, false) {
+		t.Fatal("expected a plugin-created user to always be fully rotated")
+	}
+}
+
+func TestShouldResetPassword_StaticUserRequiresOptIn(t *testing.T) {
+	createdUsers := map[string]bool{}
+
+	if shouldResetPassword("static-user", createdUsers, false) {
+		t.Fatal("expected a pre-existing static user to be left alone by default")
+	}
+	if !shouldResetPassword("static-user", createdUsers, true) {
+		t.Fatal("expected ManageExistingPasswords to opt a static user into full rotation")
+	}
+}
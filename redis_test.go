@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestEachMaster_SingleClient(t *testing.T) {
+	// go-redis dials lazily, so constructing a client doesn't require a live
+	// server; only the Do/Ping call below would.
+	client := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:0"})
+	defer client.Close()
+
+	var called *goredis.Client
+	err := eachMaster(context.Background(), client, func(ctx context.Context, shard *goredis.Client) error {
+		called = shard
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("eachMaster returned error: %v", err)
+	}
+	if called != client {
+		t.Fatal("expected fn to be called once with the single client")
+	}
+}
+
+func TestEachMaster_UnsupportedClientType(t *testing.T) {
+	err := eachMaster(context.Background(), nil, func(ctx context.Context, shard *goredis.Client) error {
+		t.Fatal("fn should not be called for an unsupported client type")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported client type")
+	}
+}
@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+func TestRotateRootCredentials_RequiresUsername(t *testing.T) {
+	db := new()
+
+	err := db.rotateRootCredentials(context.Background(), "newpassword")
+	if err == nil {
+		t.Fatal("expected an error when no username is configured")
+	}
+}
+
+func TestUpdateUser_RoutesRootUsernameToRotateRootCredentials(t *testing.T) {
+	db := new()
+	db.Username = "root-user"
+
+	req := dbplugin.UpdateUserRequest{
+		Username: "root-user",
+		Password: &dbplugin.ChangePassword{NewPassword: "newpassword"},
+	}
+
+	// No connection has been initialized, so this exercises that UpdateUser
+	// recognizes req.Username == c.Username and routes to
+	// rotateRootCredentials (which fails fast on getConnection) instead of
+	// silently falling through to changeUserPassword for the root user.
+	if _, err := db.UpdateUser(context.Background(), req); err == nil {
+		t.Fatal("expected an error when the connection producer has not been initialized")
+	}
+}